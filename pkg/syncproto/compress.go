@@ -0,0 +1,276 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// EncodeCompressedKVs gob-encodes msg and compresses the result with codec, producing
+// the MsgCompressedKVs to send in its place.  CompressionNone is accepted and simply
+// skips the compression step, so the sender doesn't need a separate code path for
+// peers that didn't negotiate a compression capability -- though in that case it's
+// simpler to just send msg as a plain MsgKVs.
+func EncodeCompressedKVs(msg MsgKVs, codec CompressionCodec) (MsgCompressedKVs, error) {
+	var raw bytes.Buffer
+	if err := gob.NewEncoder(&raw).Encode(msg); err != nil {
+		return MsgCompressedKVs{}, fmt.Errorf("syncproto: failed to gob-encode MsgKVs: %w", err)
+	}
+	payload, err := compressBytes(raw.Bytes(), codec)
+	if err != nil {
+		return MsgCompressedKVs{}, err
+	}
+	return MsgCompressedKVs{Codec: codec, Payload: payload}, nil
+}
+
+// DecodeCompressedKVs reverses EncodeCompressedKVs.
+func DecodeCompressedKVs(m MsgCompressedKVs) (MsgKVs, error) {
+	raw, err := decompressBytes(m.Payload, m.Codec)
+	if err != nil {
+		return MsgKVs{}, err
+	}
+	var msg MsgKVs
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&msg); err != nil {
+		return MsgKVs{}, fmt.Errorf("syncproto: failed to gob-decode MsgKVs: %w", err)
+	}
+	return msg, nil
+}
+
+func compressBytes(raw []byte, codec CompressionCodec) ([]byte, error) {
+	switch codec {
+	case CompressionNone:
+		return raw, nil
+	case CompressionSnappy:
+		return snappy.Encode(nil, raw), nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to create zstd encoder: %w", err)
+		}
+		defer enc.Close()
+		return enc.EncodeAll(raw, nil), nil
+	default:
+		return nil, fmt.Errorf("syncproto: unknown compression codec %q", codec)
+	}
+}
+
+func decompressBytes(payload []byte, codec CompressionCodec) ([]byte, error) {
+	switch codec {
+	case CompressionNone:
+		return payload, nil
+	case CompressionSnappy:
+		raw, err := snappy.Decode(nil, payload)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to snappy-decompress payload: %w", err)
+		}
+		return raw, nil
+	case CompressionZstd:
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to create zstd decoder: %w", err)
+		}
+		defer dec.Close()
+		raw, err := dec.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to zstd-decompress payload: %w", err)
+		}
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("syncproto: unknown compression codec %q", codec)
+	}
+}
+
+// CompressedConn wraps a net.Conn with the compression codec negotiated at handshake
+// time (see CapabilityCompressionSnappy / CapabilityCompressionZstd), so that the gob
+// Encoder/Decoder layered on top can read and write as if the connection carried plain
+// bytes. It also tracks simple per-connection metrics (see Metrics) so operators can
+// see the compression ratio being achieved and the time spent in Read and Write.
+type CompressedConn struct {
+	net.Conn
+	codec CompressionCodec
+
+	reader io.Reader
+	writer io.WriteCloser
+	// wireOut counts bytes as they actually leave the process, i.e. post-compression,
+	// so Metrics can compute a ratio against the pre-compression counts below.
+	wireOut *countingWriter
+	// wireIn counts bytes as they actually arrive from the peer, i.e. pre-decompression,
+	// the inbound equivalent of wireOut.
+	wireIn *countingReader
+
+	counters compressionCounters
+}
+
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddUint64(&c.n, uint64(n))
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddUint64(&c.n, uint64(n))
+	return n, err
+}
+
+// nopWriteCloser adapts an io.Writer with no Close method to io.WriteCloser for the
+// CompressionNone case, where there's nothing to flush or close.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewCompressedConn wraps conn for the given negotiated codec. CompressionNone is a
+// valid codec and returns a CompressedConn that passes bytes through unchanged (while
+// still tracking Metrics), so callers don't need a special case for "didn't negotiate
+// compression".
+func NewCompressedConn(conn net.Conn, codec CompressionCodec) (*CompressedConn, error) {
+	c := &CompressedConn{
+		Conn:    conn,
+		codec:   codec,
+		wireOut: &countingWriter{w: conn},
+		wireIn:  &countingReader{r: conn},
+	}
+	switch codec {
+	case CompressionNone:
+		c.reader = c.wireIn
+		c.writer = nopWriteCloser{c.wireOut}
+	case CompressionSnappy:
+		c.reader = snappy.NewReader(c.wireIn)
+		c.writer = snappy.NewBufferedWriter(c.wireOut)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(c.wireIn)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to create zstd reader: %w", err)
+		}
+		zw, err := zstd.NewWriter(c.wireOut)
+		if err != nil {
+			return nil, fmt.Errorf("syncproto: failed to create zstd writer: %w", err)
+		}
+		c.reader = zr
+		c.writer = zw
+	default:
+		return nil, fmt.Errorf("syncproto: unknown compression codec %q", codec)
+	}
+	return c, nil
+}
+
+func (c *CompressedConn) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := c.reader.Read(p)
+	atomic.AddInt64(&c.counters.decompressNanos, int64(time.Since(start)))
+	if n > 0 {
+		atomic.AddUint64(&c.counters.rawBytesIn, uint64(n))
+	}
+	return n, err
+}
+
+func (c *CompressedConn) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := c.writer.Write(p)
+	atomic.AddInt64(&c.counters.compressNanos, int64(time.Since(start)))
+	if n > 0 {
+		atomic.AddUint64(&c.counters.rawBytesOut, uint64(n))
+	}
+	return n, err
+}
+
+// Close flushes any buffered, not-yet-written compressed output before closing the
+// underlying connection.
+func (c *CompressedConn) Close() error {
+	if f, ok := c.writer.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	_ = c.writer.Close()
+	return c.Conn.Close()
+}
+
+// Metrics returns a snapshot of this connection's byte counts and the time spent in
+// Read and Write so far, in both directions, suitable for exposing as Prometheus metrics.
+func (c *CompressedConn) Metrics() CompressionMetrics {
+	return CompressionMetrics{
+		Codec:          c.codec,
+		RawBytesIn:     atomic.LoadUint64(&c.counters.rawBytesIn),
+		RawBytesOut:    atomic.LoadUint64(&c.counters.rawBytesOut),
+		WireBytesIn:    atomic.LoadUint64(&c.wireIn.n),
+		WireBytesOut:   atomic.LoadUint64(&c.wireOut.n),
+		CompressTime:   time.Duration(atomic.LoadInt64(&c.counters.compressNanos)),
+		DecompressTime: time.Duration(atomic.LoadInt64(&c.counters.decompressNanos)),
+	}
+}
+
+// compressionCounters holds the raw, atomically-updated counters behind
+// CompressionMetrics, so Metrics() can be read safely from a different goroutine than
+// the one doing the I/O.
+type compressionCounters struct {
+	rawBytesIn      uint64
+	rawBytesOut     uint64
+	compressNanos   int64
+	decompressNanos int64
+}
+
+// CompressionMetrics is a point-in-time view of a CompressedConn's compression ratio
+// and the time spent in its Read and Write calls, in both directions -- Typha and Felix
+// each mostly drive one direction of a connection (outbound KVs, inbound
+// decompression), so neither side alone is enough to judge whether compression is
+// worth its cost.  CompressTime and DecompressTime are wall-clock durations of those
+// calls, so they include any time blocked on the underlying connection and are not a
+// measure of CPU time alone.
+type CompressionMetrics struct {
+	Codec          CompressionCodec
+	RawBytesIn     uint64
+	RawBytesOut    uint64
+	WireBytesIn    uint64
+	WireBytesOut   uint64
+	CompressTime   time.Duration
+	DecompressTime time.Duration
+}
+
+// Ratio returns WireBytesOut / RawBytesOut, i.e. the fraction of the original outbound
+// size that made it onto the wire -- smaller is better. It returns 1 (no reduction)
+// before anything has been written, to avoid a misleading divide-by-zero.
+func (m CompressionMetrics) Ratio() float64 {
+	if m.RawBytesOut == 0 {
+		return 1
+	}
+	return float64(m.WireBytesOut) / float64(m.RawBytesOut)
+}
+
+// RatioIn is Ratio's inbound equivalent: WireBytesIn / RawBytesIn, the fraction of the
+// decompressed size that arrived on the wire from the peer.
+func (m CompressionMetrics) RatioIn() float64 {
+	if m.RawBytesIn == 0 {
+		return 1
+	}
+	return float64(m.WireBytesIn) / float64(m.RawBytesIn)
+}
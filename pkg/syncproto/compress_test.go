@@ -0,0 +1,181 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func testEncodeDecodeCompressedKVs(t *testing.T, codec CompressionCodec) {
+	t.Helper()
+	msg := MsgKVs{
+		KVs: []SerializedUpdate{
+			{Key: "/calico/v1/host/node-a/workload/foo", Value: bytes.Repeat([]byte("x"), 500)},
+			{Key: "/calico/v1/host/node-a/workload/bar", Revision: "123"},
+		},
+	}
+
+	compressed, err := EncodeCompressedKVs(msg, codec)
+	if err != nil {
+		t.Fatalf("EncodeCompressedKVs: %v", err)
+	}
+	if compressed.Codec != codec {
+		t.Errorf("Codec = %q, want %q", compressed.Codec, codec)
+	}
+
+	got, err := DecodeCompressedKVs(compressed)
+	if err != nil {
+		t.Fatalf("DecodeCompressedKVs: %v", err)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Errorf("round trip mismatch for codec %q: got %+v, want %+v", codec, got, msg)
+	}
+}
+
+func TestEncodeDecodeCompressedKVs_None(t *testing.T) {
+	testEncodeDecodeCompressedKVs(t, CompressionNone)
+}
+
+func TestEncodeDecodeCompressedKVs_Snappy(t *testing.T) {
+	testEncodeDecodeCompressedKVs(t, CompressionSnappy)
+}
+
+func TestEncodeDecodeCompressedKVs_Zstd(t *testing.T) {
+	testEncodeDecodeCompressedKVs(t, CompressionZstd)
+}
+
+func testCompressedConnRoundTrip(t *testing.T, codec CompressionCodec) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	cc, err := NewCompressedConn(clientConn, codec)
+	if err != nil {
+		t.Fatalf("NewCompressedConn (client): %v", err)
+	}
+	sc, err := NewCompressedConn(serverConn, codec)
+	if err != nil {
+		t.Fatalf("NewCompressedConn (server): %v", err)
+	}
+
+	payload := bytes.Repeat([]byte("hello typha, hello felix, "), 200)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := cc.Write(payload)
+		if err == nil {
+			err = cc.Close()
+		}
+		errCh <- err
+	}()
+
+	got, err := io.ReadAll(sc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("write/close: %v", err)
+	}
+
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("round trip mismatch for codec %q: got %d bytes, want %d bytes", codec, len(got), len(payload))
+	}
+
+	m := cc.Metrics()
+	if m.RawBytesOut != uint64(len(payload)) {
+		t.Errorf("RawBytesOut = %d, want %d", m.RawBytesOut, len(payload))
+	}
+	if codec != CompressionNone && m.WireBytesOut >= m.RawBytesOut {
+		t.Errorf("expected compression to shrink a repetitive payload: wire=%d raw=%d", m.WireBytesOut, m.RawBytesOut)
+	}
+	if codec == CompressionNone && m.WireBytesOut != m.RawBytesOut {
+		t.Errorf("CompressionNone should not change the byte count: wire=%d raw=%d", m.WireBytesOut, m.RawBytesOut)
+	}
+	if m.CompressTime <= 0 {
+		t.Error("expected CompressTime to record time spent in Write")
+	}
+
+	sm := sc.Metrics()
+	if sm.RawBytesIn != uint64(len(payload)) {
+		t.Errorf("RawBytesIn = %d, want %d", sm.RawBytesIn, len(payload))
+	}
+	if sm.WireBytesIn != m.WireBytesOut {
+		t.Errorf("WireBytesIn = %d, want %d (the sender's WireBytesOut)", sm.WireBytesIn, m.WireBytesOut)
+	}
+	if sm.DecompressTime <= 0 {
+		t.Error("expected DecompressTime to record time spent in Read")
+	}
+}
+
+func TestCompressedConn_RoundTrip_None(t *testing.T) {
+	testCompressedConnRoundTrip(t, CompressionNone)
+}
+
+func TestCompressedConn_RoundTrip_Snappy(t *testing.T) {
+	testCompressedConnRoundTrip(t, CompressionSnappy)
+}
+
+func TestCompressedConn_RoundTrip_Zstd(t *testing.T) {
+	testCompressedConnRoundTrip(t, CompressionZstd)
+}
+
+func TestCompressedConn_UnknownCodec(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	if _, err := NewCompressedConn(clientConn, CompressionCodec("bogus")); err == nil {
+		t.Error("expected an error for an unknown codec")
+	}
+}
+
+func TestCompressionMetrics_Ratio(t *testing.T) {
+	tests := []struct {
+		name string
+		m    CompressionMetrics
+		want float64
+	}{
+		{"no writes yet", CompressionMetrics{}, 1},
+		{"halved", CompressionMetrics{RawBytesOut: 100, WireBytesOut: 50}, 0.5},
+		{"no reduction", CompressionMetrics{RawBytesOut: 100, WireBytesOut: 100}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Ratio(); got != tt.want {
+			t.Errorf("%s: Ratio() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMetrics_RatioIn(t *testing.T) {
+	tests := []struct {
+		name string
+		m    CompressionMetrics
+		want float64
+	}{
+		{"no reads yet", CompressionMetrics{}, 1},
+		{"halved", CompressionMetrics{RawBytesIn: 100, WireBytesIn: 50}, 0.5},
+		{"no reduction", CompressionMetrics{RawBytesIn: 100, WireBytesIn: 100}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.m.RatioIn(); got != tt.want {
+			t.Errorf("%s: RatioIn() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
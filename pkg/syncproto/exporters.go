@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sdkExporter adapts an OpenTelemetry SDK SpanExporter to our Exporter interface,
+// batching spans before handing them to the backend-specific exporter underneath.
+type sdkExporter struct {
+	spanExporter sdktrace.SpanExporter
+	serviceName  string
+}
+
+func (e *sdkExporter) TracerProvider() (trace.TracerProvider, func(context.Context) error, error) {
+	res, err := resource.New(context.Background(), resource.WithAttributes(semconv.ServiceName(e.serviceName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("syncproto: failed to build tracing resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(e.spanExporter),
+		sdktrace.WithResource(res),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+// NewJaegerExporter builds an Exporter that reports spans to a Jaeger agent at
+// agentEndpoint (host[:port]), for the TracingExporter="jaeger" typha.cfg setting.
+func NewJaegerExporter(serviceName, agentEndpoint string) (Exporter, error) {
+	opts := []jaeger.AgentEndpointOption{jaeger.WithAgentHost(agentEndpoint)}
+	if host, port, err := net.SplitHostPort(agentEndpoint); err == nil {
+		opts = []jaeger.AgentEndpointOption{jaeger.WithAgentHost(host), jaeger.WithAgentPort(port)}
+	}
+	exp, err := jaeger.New(jaeger.WithAgentEndpoint(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("syncproto: failed to create jaeger exporter: %w", err)
+	}
+	return &sdkExporter{spanExporter: exp, serviceName: serviceName}, nil
+}
+
+// NewZipkinExporter builds an Exporter that reports spans to a Zipkin collector at
+// collectorURL, for the TracingExporter="zipkin" typha.cfg setting.
+func NewZipkinExporter(serviceName, collectorURL string) (Exporter, error) {
+	exp, err := zipkin.New(collectorURL)
+	if err != nil {
+		return nil, fmt.Errorf("syncproto: failed to create zipkin exporter: %w", err)
+	}
+	return &sdkExporter{spanExporter: exp, serviceName: serviceName}, nil
+}
+
+// NewOTLPExporter builds an Exporter that reports spans over OTLP/gRPC to endpoint,
+// for the TracingExporter="otlp" typha.cfg setting.
+func NewOTLPExporter(ctx context.Context, serviceName, endpoint string) (Exporter, error) {
+	exp, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("syncproto: failed to create otlp exporter: %w", err)
+	}
+	return &sdkExporter{spanExporter: exp, serviceName: serviceName}, nil
+}
+
+// NewExporter builds the Exporter selected by kind, reading the TracingExporter and
+// TracingEndpoint typha.cfg keys (kind and endpoint here).  ExporterNone returns a nil
+// Exporter and no error, for the case where tracing is disabled.
+func NewExporter(ctx context.Context, kind ExporterKind, serviceName, endpoint string) (Exporter, error) {
+	switch kind {
+	case ExporterNone:
+		return nil, nil
+	case ExporterJaeger:
+		return NewJaegerExporter(serviceName, endpoint)
+	case ExporterZipkin:
+		return NewZipkinExporter(serviceName, endpoint)
+	case ExporterOTLP:
+		return NewOTLPExporter(ctx, serviceName, endpoint)
+	default:
+		return nil, fmt.Errorf("syncproto: unknown tracing exporter kind %q", kind)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proto holds the protobuf definition of the Felix/Typha protocol (sync.proto),
+// the wire format intended to be served on syncproto.DefaultProtobufPort for peers that
+// negotiate syncproto.CapabilityProtobufWire instead of dialing the gob port.
+//
+// This package does not yet contain the generated Go bindings for sync.proto, the
+// protobuf listener, or a client dial path; those need a protoc-equipped build and the
+// wider connection-handling machinery that this checkout doesn't have. What it does
+// provide is the length-delimited framing the listener will use to split a byte stream
+// into messages regardless of which side is speaking protobuf: see
+// WriteLengthDelimited/ReadLengthDelimited in framing.go.
+//
+// Generate the Go bindings once protoc is available with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative sync.proto
+package proto
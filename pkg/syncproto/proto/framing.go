@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MaxMessageBytes bounds the length prefix ReadLengthDelimited will accept, so a
+// corrupt or hostile peer can't make it allocate an unbounded buffer.
+const MaxMessageBytes = 64 * 1024 * 1024
+
+// WriteLengthDelimited writes msg to w as a varint length prefix followed by msg
+// itself, the framing the protobuf listener uses to split a byte stream into messages.
+func WriteLengthDelimited(w io.Writer, msg []byte) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(msg)))
+	if _, err := w.Write(lenBuf[:n]); err != nil {
+		return fmt.Errorf("proto: failed to write length prefix: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("proto: failed to write message body: %w", err)
+	}
+	return nil
+}
+
+// byteReader is what ReadLengthDelimited needs: ReadUvarint wants a ByteReader to read
+// the length prefix one byte at a time, and io.ReadFull wants a plain Reader to read the
+// message body in one call. A *bufio.Reader, which every real caller will have anyway to
+// get buffered reads off a net.Conn, satisfies both.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// ReadLengthDelimited reads one WriteLengthDelimited-framed message from r.
+func ReadLengthDelimited(r byteReader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("proto: failed to read length prefix: %w", err)
+	}
+	if length > MaxMessageBytes {
+		return nil, fmt.Errorf("proto: message length %d exceeds MaxMessageBytes %d", length, MaxMessageBytes)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, fmt.Errorf("proto: failed to read message body: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestLengthDelimited_RoundTrip(t *testing.T) {
+	messages := [][]byte{
+		[]byte(""),
+		[]byte("a"),
+		[]byte("hello, typha"),
+		bytes.Repeat([]byte("x"), 1000),
+	}
+
+	var buf bytes.Buffer
+	for _, msg := range messages {
+		if err := WriteLengthDelimited(&buf, msg); err != nil {
+			t.Fatalf("WriteLengthDelimited(%q): %v", msg, err)
+		}
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, want := range messages {
+		got, err := ReadLengthDelimited(r)
+		if err != nil {
+			t.Fatalf("ReadLengthDelimited: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadLengthDelimited() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLengthDelimited_MultipleMessagesInterleaved(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLengthDelimited(&buf, []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteLengthDelimited(&buf, []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&buf)
+	first, err := ReadLengthDelimited(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != "first" {
+		t.Errorf("first message = %q, want %q", first, "first")
+	}
+	second, err := ReadLengthDelimited(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(second) != "second" {
+		t.Errorf("second message = %q, want %q", second, "second")
+	}
+}
+
+func TestReadLengthDelimited_RejectsOversizedLength(t *testing.T) {
+	var buf bytes.Buffer
+	var lenBuf [10]byte
+	n := binary.PutUvarint(lenBuf[:], MaxMessageBytes+1)
+	buf.Write(lenBuf[:n])
+
+	r := bufio.NewReader(&buf)
+	if _, err := ReadLengthDelimited(r); err == nil {
+		t.Error("expected an error for a length prefix exceeding MaxMessageBytes, got nil")
+	}
+}
+
+func TestReadLengthDelimited_TruncatedBody(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLengthDelimited(&buf, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-2]
+
+	r := bufio.NewReader(bytes.NewReader(truncated))
+	if _, err := ReadLengthDelimited(r); err == nil {
+		t.Error("expected an error for a truncated message body, got nil")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import "sync"
+
+// DefaultResumeBufferSize is the default number of SerializedUpdates that a
+// ResumeBuffer retains, bounding how far back a reconnecting client can resume from.
+const DefaultResumeBufferSize = 4096
+
+// ResumeBuffer is a bounded ring buffer of the most recently emitted SerializedUpdates
+// for one syncer type, keyed by revision, so that a reconnecting client can resume the
+// stream from where it left off instead of triggering a full re-snapshot.  It borrows
+// the etcd ModRevision/compare pattern: Lookup finds the revision the client last saw
+// through a revision-to-sequence index and, if it's still present, returns everything
+// sent after it.
+//
+// A ResumeBuffer is safe for concurrent use: Add is expected to be called from the
+// single goroutine that's serializing updates for this syncer type, while Lookup is
+// called from each client's connection goroutine as it handshakes.
+type ResumeBuffer struct {
+	syncerType string
+	capacity   int
+
+	mutex   sync.Mutex
+	updates []SerializedUpdate // oldest first
+	// firstSeq is the sequence number of updates[0]; sequence numbers increase by one
+	// for every update ever added, so they survive entries being evicted from the ring.
+	firstSeq int64
+	nextSeq  int64
+	// seqByRevision maps a still-buffered revision to its sequence number.
+	seqByRevision map[string]int64
+}
+
+// NewResumeBuffer creates a ResumeBuffer for the given syncer type that retains at most
+// capacity updates.  A capacity <= 0 is treated as DefaultResumeBufferSize.
+func NewResumeBuffer(syncerType string, capacity int) *ResumeBuffer {
+	if capacity <= 0 {
+		capacity = DefaultResumeBufferSize
+	}
+	return &ResumeBuffer{
+		syncerType:    syncerType,
+		capacity:      capacity,
+		seqByRevision: map[string]int64{},
+	}
+}
+
+// Add records u as the most recently emitted update, evicting the oldest buffered
+// update if the buffer is already at capacity.
+func (b *ResumeBuffer) Add(u SerializedUpdate) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	seq := b.nextSeq
+	b.nextSeq++
+	b.updates = append(b.updates, u)
+	if u.Revision != "" {
+		b.seqByRevision[u.Revision] = seq
+	}
+
+	if len(b.updates) > b.capacity {
+		evicted := b.updates[0]
+		b.updates = b.updates[1:]
+		b.firstSeq++
+		// Only clear the index entry if it's still pointing at the entry we just
+		// evicted: a later update may have reused the same revision string.
+		if seq, ok := b.seqByRevision[evicted.Revision]; ok && seq == b.firstSeq-1 {
+			delete(b.seqByRevision, evicted.Revision)
+		}
+	}
+}
+
+// Lookup returns the updates sent strictly after revision, for resuming a connection
+// from a client that last saw that revision from a syncer of type syncerType.  If the
+// revision is no longer available (or was never from this syncer type), it returns a
+// MsgResumeNack explaining why and the caller should fall back to a full snapshot.
+func (b *ResumeBuffer) Lookup(syncerType, revision string) ([]SerializedUpdate, *MsgResumeNack) {
+	if syncerType != b.syncerType {
+		return nil, &MsgResumeNack{Reason: ResumeNackReasonWrongSyncerType}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	seq, ok := b.seqByRevision[revision]
+	if !ok || seq < b.firstSeq {
+		return nil, &MsgResumeNack{Reason: ResumeNackReasonAgedOut}
+	}
+
+	offset := int(seq - b.firstSeq + 1)
+	if offset >= len(b.updates) {
+		return nil, nil
+	}
+	deltas := make([]SerializedUpdate, len(b.updates)-offset)
+	copy(deltas, b.updates[offset:])
+	return deltas, nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestResumeBuffer_SuccessfulResume(t *testing.T) {
+	b := NewResumeBuffer("felix", 10)
+	for i := 0; i < 5; i++ {
+		b.Add(SerializedUpdate{Key: fmt.Sprintf("key-%d", i), Revision: fmt.Sprintf("rev-%d", i)})
+	}
+
+	deltas, nack := b.Lookup("felix", "rev-2")
+	if nack != nil {
+		t.Fatalf("unexpected nack: %+v", nack)
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("expected 2 deltas after rev-2, got %d: %+v", len(deltas), deltas)
+	}
+	if deltas[0].Revision != "rev-3" || deltas[1].Revision != "rev-4" {
+		t.Errorf("unexpected deltas: %+v", deltas)
+	}
+}
+
+func TestResumeBuffer_RevisionAgedOut(t *testing.T) {
+	b := NewResumeBuffer("felix", 3)
+	for i := 0; i < 10; i++ {
+		b.Add(SerializedUpdate{Key: fmt.Sprintf("key-%d", i), Revision: fmt.Sprintf("rev-%d", i)})
+	}
+
+	// rev-0 through rev-6 have all aged out of a 3-entry buffer by the time rev-9 lands.
+	deltas, nack := b.Lookup("felix", "rev-0")
+	if nack == nil {
+		t.Fatalf("expected a nack for an aged-out revision, got deltas %+v", deltas)
+	}
+	if nack.Reason != ResumeNackReasonAgedOut {
+		t.Errorf("Reason = %q, want %q", nack.Reason, ResumeNackReasonAgedOut)
+	}
+
+	// The most recent revisions are still resolvable.
+	if _, nack := b.Lookup("felix", "rev-9"); nack != nil {
+		t.Errorf("unexpected nack for a still-buffered revision: %+v", nack)
+	}
+}
+
+func TestResumeBuffer_WrongSyncerType(t *testing.T) {
+	b := NewResumeBuffer("felix", 10)
+	b.Add(SerializedUpdate{Key: "key-0", Revision: "rev-0"})
+
+	deltas, nack := b.Lookup("bgp", "rev-0")
+	if nack == nil {
+		t.Fatalf("expected a nack for a revision from a different syncer type, got deltas %+v", deltas)
+	}
+	if nack.Reason != ResumeNackReasonWrongSyncerType {
+		t.Errorf("Reason = %q, want %q", nack.Reason, ResumeNackReasonWrongSyncerType)
+	}
+}
+
+func TestResumeBuffer_LookupAtHead(t *testing.T) {
+	b := NewResumeBuffer("felix", 10)
+	b.Add(SerializedUpdate{Key: "key-0", Revision: "rev-0"})
+
+	deltas, nack := b.Lookup("felix", "rev-0")
+	if nack != nil {
+		t.Fatalf("unexpected nack: %+v", nack)
+	}
+	if len(deltas) != 0 {
+		t.Errorf("expected no deltas when resuming from the most recent revision, got %+v", deltas)
+	}
+}
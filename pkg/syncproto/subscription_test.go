@@ -0,0 +1,102 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import "testing"
+
+func TestSubscription_NilMatchesEverything(t *testing.T) {
+	var s *Subscription
+	if !s.Matches("WorkloadEndpoint", SerializedUpdate{Key: "/calico/v1/host/a/workload/b"}, nil) {
+		t.Error("nil Subscription should match everything")
+	}
+}
+
+func TestSubscription_ResourceKinds(t *testing.T) {
+	s := &Subscription{ResourceKinds: []string{"WorkloadEndpoint", "Policy"}}
+
+	if !s.Matches("WorkloadEndpoint", SerializedUpdate{}, nil) {
+		t.Error("expected a listed kind to match")
+	}
+	if s.Matches("Profile", SerializedUpdate{}, nil) {
+		t.Error("expected an unlisted kind not to match")
+	}
+}
+
+func TestSubscription_KeyPrefixes(t *testing.T) {
+	s := &Subscription{KeyPrefixes: []string{"/calico/v1/host/node-a/"}}
+
+	if !s.Matches("", SerializedUpdate{Key: "/calico/v1/host/node-a/workload/foo"}, nil) {
+		t.Error("expected a matching prefix to match")
+	}
+	if s.Matches("", SerializedUpdate{Key: "/calico/v1/host/node-b/workload/foo"}, nil) {
+		t.Error("expected a non-matching prefix not to match")
+	}
+}
+
+func TestSubscription_Selector(t *testing.T) {
+	s := &Subscription{Selector: "node = node-a, role=worker"}
+
+	if !s.Matches("", SerializedUpdate{}, map[string]string{"node": "node-a", "role": "worker"}) {
+		t.Error("expected matching labels to match")
+	}
+	if s.Matches("", SerializedUpdate{}, map[string]string{"node": "node-b", "role": "worker"}) {
+		t.Error("expected a differing label value not to match")
+	}
+	if s.Matches("", SerializedUpdate{}, nil) {
+		t.Error("expected no labels not to match a non-empty selector")
+	}
+}
+
+func TestSubscription_CombinedFilters(t *testing.T) {
+	s := &Subscription{
+		ResourceKinds: []string{"WorkloadEndpoint"},
+		KeyPrefixes:   []string{"/calico/v1/host/node-a/"},
+		Selector:      "node=node-a",
+	}
+
+	match := SerializedUpdate{Key: "/calico/v1/host/node-a/workload/foo"}
+	if !s.Matches("WorkloadEndpoint", match, map[string]string{"node": "node-a"}) {
+		t.Error("expected an update satisfying all three filters to match")
+	}
+	if s.Matches("Policy", match, map[string]string{"node": "node-a"}) {
+		t.Error("expected a wrong-kind update not to match even if prefix/selector match")
+	}
+}
+
+func TestMatchesSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{"empty selector matches anything", "", map[string]string{"a": "b"}, true},
+		{"empty selector matches nil labels", "", nil, true},
+		{"single term match", "a=b", map[string]string{"a": "b"}, true},
+		{"single term mismatch", "a=b", map[string]string{"a": "c"}, false},
+		{"missing label", "a=b", map[string]string{}, false},
+		{"multi term all match", "a=b,c=d", map[string]string{"a": "b", "c": "d"}, true},
+		{"multi term one mismatch", "a=b,c=d", map[string]string{"a": "b", "c": "x"}, false},
+		{"whitespace tolerant", " a = b , c = d ", map[string]string{"a": "b", "c": "d"}, true},
+		{"malformed term", "a", map[string]string{"a": ""}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesSelector(tt.selector, tt.labels); got != tt.want {
+				t.Errorf("matchesSelector(%q, %v) = %v, want %v", tt.selector, tt.labels, got, tt.want)
+			}
+		})
+	}
+}
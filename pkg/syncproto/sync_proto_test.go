@@ -0,0 +1,156 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+func TestCommonCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		ours, theirs []string
+		want         []string
+	}{
+		{"both empty", nil, nil, nil},
+		{"we have none", nil, []string{CapabilityTracing}, nil},
+		{"they have none", []string{CapabilityTracing}, nil, nil},
+		{
+			"full overlap",
+			[]string{CapabilityTracing, CapabilityDeltaResume},
+			[]string{CapabilityDeltaResume, CapabilityTracing},
+			[]string{CapabilityTracing, CapabilityDeltaResume},
+		},
+		{
+			"partial overlap preserves our order",
+			[]string{CapabilityProtobufWire, CapabilityTracing, CapabilityKindFilter},
+			[]string{CapabilityKindFilter, CapabilityProtobufWire},
+			[]string{CapabilityProtobufWire, CapabilityKindFilter},
+		},
+		{
+			"no overlap",
+			[]string{CapabilityCompressionSnappy},
+			[]string{CapabilityCompressionZstd},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CommonCapabilities(tt.ours, tt.theirs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CommonCapabilities(%v, %v) = %v, want %v", tt.ours, tt.theirs, got, tt.want)
+			}
+		})
+	}
+}
+
+// oldMsgClientHello mirrors the fields MsgClientHello had before ProtocolVersion and
+// Capabilities were added, standing in for a pre-negotiation Felix/Typha binary in the
+// round-trip tests below.
+type oldMsgClientHello struct {
+	Hostname string
+	Info     string
+	Version  string
+}
+
+// TestHandshakeRoundTrip_OldSenderNewReceiver simulates an old Felix (or Typha) that has
+// never heard of ProtocolVersion/Capabilities connecting to an uplevel peer: gob should
+// default the missing fields to the zero value rather than erroring.
+func TestHandshakeRoundTrip_OldSenderNewReceiver(t *testing.T) {
+	sent := oldMsgClientHello{
+		Hostname: "felix-1",
+		Info:     "old felix",
+		Version:  "v3.1.0",
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sent); err != nil {
+		t.Fatalf("failed to encode old-style MsgClientHello: %v", err)
+	}
+
+	var received MsgClientHello
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatalf("failed to decode old-style MsgClientHello into current struct: %v", err)
+	}
+
+	if received.Hostname != sent.Hostname || received.Info != sent.Info || received.Version != sent.Version {
+		t.Errorf("shared fields did not round-trip: got %+v, sent %+v", received, sent)
+	}
+	if received.ProtocolVersion != 0 {
+		t.Errorf("ProtocolVersion = %d, want 0 for a peer that never sent it", received.ProtocolVersion)
+	}
+	if len(received.Capabilities) != 0 {
+		t.Errorf("Capabilities = %v, want empty for a peer that never sent it", received.Capabilities)
+	}
+	if CommonCapabilities([]string{CapabilityTracing}, received.Capabilities) != nil {
+		t.Errorf("expected no common capabilities with an old peer")
+	}
+}
+
+// TestHandshakeRoundTrip_NewSenderOldReceiver simulates an uplevel Felix connecting to
+// an old Typha binary: the new fields should simply be dropped by gob, and the fields
+// the old struct knows about should still come through.
+func TestHandshakeRoundTrip_NewSenderOldReceiver(t *testing.T) {
+	sent := MsgClientHello{
+		Hostname:        "felix-2",
+		Info:            "new felix",
+		Version:         "v3.99.0",
+		ProtocolVersion: 3,
+		Capabilities:    []string{CapabilityTracing, CapabilityDeltaResume},
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sent); err != nil {
+		t.Fatalf("failed to encode MsgClientHello: %v", err)
+	}
+
+	var received oldMsgClientHello
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatalf("failed to decode new-style MsgClientHello into old struct: %v", err)
+	}
+
+	if received.Hostname != sent.Hostname || received.Info != sent.Info || received.Version != sent.Version {
+		t.Errorf("shared fields did not round-trip: got %+v, sent %+v", received, sent)
+	}
+}
+
+// TestHandshakeRoundTrip_BothNew is the base case: two uplevel peers should see every
+// field, including the new ones, round-trip exactly.
+func TestHandshakeRoundTrip_BothNew(t *testing.T) {
+	sent := MsgClientHello{
+		Hostname:        "felix-3",
+		Info:            "new felix",
+		Version:         "v3.99.0",
+		ProtocolVersion: 3,
+		Capabilities:    []string{CapabilityTracing, CapabilityDeltaResume},
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sent); err != nil {
+		t.Fatalf("failed to encode MsgClientHello: %v", err)
+	}
+
+	var received MsgClientHello
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatalf("failed to decode MsgClientHello: %v", err)
+	}
+
+	if !reflect.DeepEqual(sent, received) {
+		t.Errorf("round trip mismatch: sent %+v, received %+v", sent, received)
+	}
+}
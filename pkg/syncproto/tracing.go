@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContextPropagator encodes/decodes the W3C traceparent/tracestate pair that
+// MsgClientHello/MsgKVs/MsgSyncStatus carry in their TraceContext field.
+var traceContextPropagator = propagation.TraceContext{}
+
+// InjectTraceContext extracts the W3C traceparent/tracestate for ctx's current span,
+// in the map shape carried by TraceContext fields on the wire.  It returns nil if ctx
+// has no active span, so callers can assign the result straight to a TraceContext
+// field and get the pre-tracing behaviour (an absent field) for free.
+func InjectTraceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	traceContextPropagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return map[string]string(carrier)
+}
+
+// ExtractTraceContext returns a context carrying tc as its remote span context, so the
+// receiver of a message can start a span that's a child of the sender's.  It returns ctx
+// unchanged if tc is empty, which covers both "tracing wasn't negotiated" and "the
+// sender had no active span".
+func ExtractTraceContext(ctx context.Context, tc map[string]string) context.Context {
+	if len(tc) == 0 {
+		return ctx
+	}
+	return traceContextPropagator.Extract(ctx, propagation.MapCarrier(tc))
+}
+
+// tracerName identifies this package's spans to whichever TracerProvider is registered
+// as the process-wide default (see Exporter.TracerProvider).
+const tracerName = "github.com/projectcalico/typha/pkg/syncproto"
+
+// Tracer returns the Tracer that StartConnectionSpan/StartKVsSpan/StartSyncStatusSpan
+// use to create spans, from the globally-registered TracerProvider.  If the process
+// never configured an Exporter, this is otel's default no-op tracer, so calling these
+// functions is always safe even when tracing isn't enabled.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartConnectionSpan starts the span covering a Felix/Typha connection, linked to the
+// span (if any) that ctx/hello.TraceContext identify as having initiated it, and
+// refreshes hello.TraceContext so the link can be forwarded further if needed.
+func StartConnectionSpan(ctx context.Context, hello *MsgClientHello) (context.Context, trace.Span) {
+	ctx = ExtractTraceContext(ctx, hello.TraceContext)
+	ctx, span := Tracer().Start(ctx, "typha.connection")
+	span.SetAttributes(attribute.String("typha.hostname", hello.Hostname))
+	hello.TraceContext = InjectTraceContext(ctx)
+	return ctx, span
+}
+
+// StartKVsSpan starts the span covering the delivery of one MsgKVs, tagged with the
+// attributes an operator would want when following a datastore write through Typha's
+// cache to Felix apply, and refreshes msg.TraceContext so the receiving side can link
+// its own span to this one.
+func StartKVsSpan(ctx context.Context, syncerType string, msg *MsgKVs) (context.Context, trace.Span) {
+	ctx = ExtractTraceContext(ctx, msg.TraceContext)
+	ctx, span := Tracer().Start(ctx, "typha.kvs")
+	span.SetAttributes(
+		attribute.String("typha.syncer_type", syncerType),
+		attribute.Int("typha.kv_count", len(msg.KVs)),
+	)
+	for _, kv := range msg.KVs {
+		if kv.Revision != "" {
+			span.SetAttributes(attribute.String("typha.revision", kv.Revision))
+			break
+		}
+	}
+	msg.TraceContext = InjectTraceContext(ctx)
+	return ctx, span
+}
+
+// StartSyncStatusSpan starts the span covering one sync-status transition, and
+// refreshes msg.TraceContext for the same reason as StartKVsSpan.
+func StartSyncStatusSpan(ctx context.Context, syncerType string, msg *MsgSyncStatus) (context.Context, trace.Span) {
+	ctx = ExtractTraceContext(ctx, msg.TraceContext)
+	ctx, span := Tracer().Start(ctx, "typha.sync_status")
+	span.SetAttributes(
+		attribute.String("typha.syncer_type", syncerType),
+		attribute.String("typha.sync_status", fmt.Sprintf("%v", msg.SyncStatus)),
+	)
+	msg.TraceContext = InjectTraceContext(ctx)
+	return ctx, span
+}
+
+// Exporter is the interface a tracing backend must implement to be pluggable into
+// Typha and Felix via the TracingExporter typha.cfg key (see ExporterKind).
+type Exporter interface {
+	// TracerProvider returns the provider to register as the process-wide default
+	// (see Tracer), and a shutdown func to flush/close it when the process exits.
+	TracerProvider() (trace.TracerProvider, func(context.Context) error, error)
+}
+
+// ExporterKind identifies the backend an Exporter talks to; it matches the value of
+// the TracingExporter typha.cfg key.
+type ExporterKind string
+
+const (
+	ExporterNone   ExporterKind = ""
+	ExporterJaeger ExporterKind = "jaeger"
+	ExporterZipkin ExporterKind = "zipkin"
+	ExporterOTLP   ExporterKind = "otlp"
+)
@@ -0,0 +1,81 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestInjectExtractTraceContext_RoundTrip(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "test-span")
+	defer span.End()
+
+	tc := InjectTraceContext(ctx)
+	if len(tc) == 0 {
+		t.Fatal("expected a non-empty TraceContext for a context with an active span")
+	}
+	if _, ok := tc["traceparent"]; !ok {
+		t.Errorf("expected a traceparent key, got %v", tc)
+	}
+
+	extracted := ExtractTraceContext(context.Background(), tc)
+	_, childSpan := tp.Tracer("test").Start(extracted, "child")
+	defer childSpan.End()
+
+	if childSpan.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("child span TraceID = %v, want %v", childSpan.SpanContext().TraceID(), span.SpanContext().TraceID())
+	}
+}
+
+func TestExtractTraceContext_EmptyIsNoop(t *testing.T) {
+	ctx := context.Background()
+	if got := ExtractTraceContext(ctx, nil); got != ctx {
+		t.Error("ExtractTraceContext with an empty map should return the same context")
+	}
+}
+
+func TestInjectTraceContext_NoSpanReturnsNil(t *testing.T) {
+	if got := InjectTraceContext(context.Background()); got != nil {
+		t.Errorf("expected nil TraceContext for a context with no span, got %v", got)
+	}
+}
+
+func TestStartKVsSpan_LinksToSenderSpan(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "root")
+	defer span.End()
+
+	msg := &MsgKVs{TraceContext: InjectTraceContext(ctx), KVs: []SerializedUpdate{{Revision: "123"}}}
+
+	// Simulate the receiving side starting fresh, from only the wire message.
+	_, kvSpan := StartKVsSpan(context.Background(), "felix", msg)
+	defer kvSpan.End()
+
+	if kvSpan.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("KVs span TraceID = %v, want %v (linked to the sender's span)",
+			kvSpan.SpanContext().TraceID(), span.SpanContext().TraceID())
+	}
+	if msg.TraceContext == nil {
+		t.Error("expected StartKVsSpan to refresh msg.TraceContext for forwarding onward")
+	}
+}
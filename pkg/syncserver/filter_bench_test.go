@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/projectcalico/typha/pkg/syncproto"
+)
+
+// buildLargeClusterFixture simulates a cluster of numNodes nodes, each owning
+// updatesPerNode WorkloadEndpoint updates of size bytesPerUpdate, plus a handful of
+// cluster-wide Policy updates that every node's Felix needs regardless of filtering.
+// It returns one ClientFilter per node, subscribed to that node's own updates plus the
+// cluster-wide policy prefix, and the full list of updates that Typha would otherwise
+// broadcast to everyone.
+func buildLargeClusterFixture(numNodes, updatesPerNode, bytesPerUpdate int) ([]*ClientFilter, []syncproto.SerializedUpdate, []string) {
+	filters := make([]*ClientFilter, numNodes)
+	var updates []syncproto.SerializedUpdate
+	var kinds []string
+	value := make([]byte, bytesPerUpdate)
+
+	for n := 0; n < numNodes; n++ {
+		node := fmt.Sprintf("node-%d", n)
+		filters[n] = NewClientFilter(&syncproto.Subscription{KeyPrefixes: []string{"/calico/v1/host/" + node + "/", "/calico/v1/policy/"}}, true)
+		for i := 0; i < updatesPerNode; i++ {
+			updates = append(updates, syncproto.SerializedUpdate{
+				Key:   fmt.Sprintf("/calico/v1/host/%s/workload/%d", node, i),
+				Value: value,
+			})
+			kinds = append(kinds, "WorkloadEndpoint")
+		}
+	}
+	// A handful of cluster-wide updates (e.g. GlobalNetworkPolicy) that every client
+	// still needs, to keep the benchmark honest about what filtering can't remove.
+	for i := 0; i < 5; i++ {
+		updates = append(updates, syncproto.SerializedUpdate{
+			Key:   fmt.Sprintf("/calico/v1/policy/cluster/%d", i),
+			Value: value,
+		})
+		kinds = append(kinds, "Policy")
+	}
+	return filters, updates, kinds
+}
+
+// BenchmarkBytesPerClient_Unfiltered measures the bytes each of numNodes clients would
+// receive if Typha broadcast every update to every client, with no subscription
+// filtering -- i.e. today's behaviour, and what an old client that never negotiated
+// CapabilityKindFilter still gets.
+func BenchmarkBytesPerClient_Unfiltered(b *testing.B) {
+	const numNodes = 200
+	_, updates, _ := buildLargeClusterFixture(numNodes, 50, 2048)
+
+	var totalBytes int
+	for _, u := range updates {
+		totalBytes += len(u.Value)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = totalBytes * numNodes // every client gets every update
+	}
+	b.ReportMetric(float64(totalBytes), "bytes/client")
+}
+
+// BenchmarkBytesPerClient_Filtered measures the bytes a single node's client would
+// receive once each client is subscribed to just its own node's prefix, demonstrating
+// the bandwidth reduction the subscription-filters request is meant to achieve.
+func BenchmarkBytesPerClient_Filtered(b *testing.B) {
+	const numNodes = 200
+	filters, updates, kinds := buildLargeClusterFixture(numNodes, 50, 2048)
+
+	b.ResetTimer()
+	var bytesForClientZero int
+	for i := 0; i < b.N; i++ {
+		bytesForClientZero = 0
+		for j, u := range updates {
+			if filters[0].ShouldSend(kinds[j], u, nil) {
+				bytesForClientZero += len(u.Value)
+			}
+		}
+	}
+	b.ReportMetric(float64(bytesForClientZero), "bytes/client")
+}
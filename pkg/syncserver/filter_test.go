@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncserver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/projectcalico/typha/pkg/syncproto"
+)
+
+func TestClientFilter_NoCapability_PassesEverything(t *testing.T) {
+	f := NewClientFilter(&syncproto.Subscription{ResourceKinds: []string{"Policy"}}, false)
+	if !f.ShouldSend("WorkloadEndpoint", syncproto.SerializedUpdate{}, nil) {
+		t.Error("a client that didn't negotiate the capability should get everything, regardless of Subscription")
+	}
+}
+
+func TestClientFilter_NoSubscription_PassesEverything(t *testing.T) {
+	f := NewClientFilter(nil, true)
+	if !f.ShouldSend("WorkloadEndpoint", syncproto.SerializedUpdate{}, nil) {
+		t.Error("a nil Subscription should still match everything")
+	}
+}
+
+func TestClientFilter_FiltersByKind(t *testing.T) {
+	f := NewClientFilter(&syncproto.Subscription{ResourceKinds: []string{"WorkloadEndpoint"}}, true)
+	if !f.ShouldSend("WorkloadEndpoint", syncproto.SerializedUpdate{}, nil) {
+		t.Error("expected the subscribed kind to be sent")
+	}
+	if f.ShouldSend("Policy", syncproto.SerializedUpdate{}, nil) {
+		t.Error("expected an unsubscribed kind to be filtered out")
+	}
+}
+
+func TestFilterUpdateForClients(t *testing.T) {
+	filters := []*ClientFilter{
+		NewClientFilter(&syncproto.Subscription{ResourceKinds: []string{"WorkloadEndpoint"}}, true),
+		NewClientFilter(&syncproto.Subscription{ResourceKinds: []string{"Policy"}}, true),
+		NewClientFilter(nil, false), // unfiltered old client
+	}
+
+	got := FilterUpdateForClients(filters, "WorkloadEndpoint", syncproto.SerializedUpdate{}, nil)
+	want := []int{0, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterUpdateForClients() = %v, want %v", got, want)
+	}
+}